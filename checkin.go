@@ -3,6 +3,7 @@ package checkin
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // CheckinRecord 打卡记录（使用位运算）
@@ -91,6 +92,18 @@ func (c CheckinRecord) Clear() CheckinRecord {
 	return 0
 }
 
+// shiftBy 将记录左移n位，表示时间推移了n天（n为锚点日期到目标日期的天数差）
+// n<=0 时原样返回，n>=64 时历史已全部滚出窗口，返回0
+func (c CheckinRecord) shiftBy(n int) CheckinRecord {
+	if n <= 0 {
+		return c
+	}
+	if n >= 64 {
+		return 0
+	}
+	return c << n
+}
+
 // String 打卡记录的字符串表示（用于调试）
 // 例如："✓✓✗✓✓✓✓" 表示最近7天的打卡情况
 func (c CheckinRecord) String() string {
@@ -173,3 +186,148 @@ func (c CheckinRecord) CheckinRate(days int) float64 {
 	total := c.TotalDaysInPeriod(days)
 	return float64(total) / float64(days)
 }
+
+// Frequency 聚合频率
+type Frequency int
+
+const (
+	Daily   Frequency = iota // 按天聚合（即原始位图）
+	Weekly                   // 按7天一周聚合
+	Monthly                  // 按30天一个月近似聚合
+)
+
+// daysPerWeek/daysPerMonth 滑动窗口的天数。CheckinRecord/CheckinRecordN本身不带日历信息，
+// 月份统一按30天近似，不对应自然月边界——真正按自然月对齐需要知道bit0对应的日历日期，
+// 见 MonthlyStreakFromAnchor
+const (
+	daysPerWeek  = 7
+	daysPerMonth = 30
+)
+
+// windowDaysOf 返回指定频率对应的窗口天数
+func windowDaysOf(freq Frequency) int {
+	switch freq {
+	case Weekly:
+		return daysPerWeek
+	case Monthly:
+		return daysPerMonth
+	default:
+		return 1
+	}
+}
+
+// WeeklyStreak 从今天所在的这一周开始，连续多少周满足"当周至少minDaysPerWeek天打卡"
+func (c CheckinRecord) WeeklyStreak(minDaysPerWeek int) int {
+	return c.streakByWindow(daysPerWeek, minDaysPerWeek)
+}
+
+// MonthlyStreak 从今天所在的这个月开始，连续多少个月满足"当月至少minDaysPerMonth天打卡"。
+// 这里的"月"是30天的近似滑动窗口，不对齐自然月边界；需要对齐自然月时用 MonthlyStreakFromAnchor
+func (c CheckinRecord) MonthlyStreak(minDaysPerMonth int) int {
+	return c.streakByWindow(daysPerMonth, minDaysPerMonth)
+}
+
+// MonthlyStreakFromAnchor 从anchor（位图第0位对应的日历日期）所在的自然月开始往回数，
+// 返回连续多少个自然月满足"当月至少minDaysPerMonth天打卡"。CheckinRecord本身不带日历信息，
+// 月份边界（每月28~31天不等）必须靠调用方传入anchor才能对齐，这也是为什么这个方法
+// 不是 MonthlyStreak(minDaysPerMonth) 的默认行为——大多数调用场景不需要日历精度，
+// 犯不上强制所有调用方都提供anchor
+func (c CheckinRecord) MonthlyStreakFromAnchor(anchor time.Time, minDaysPerMonth int) int {
+	streak := 0
+	for _, b := range calendarMonthBuckets(anchor, 64) {
+		count := 0
+		for day := b.start; day < b.start+b.days; day++ {
+			if c.IsCheckedDay(day) {
+				count++
+			}
+		}
+		if count < minDaysPerMonth {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// streakByWindow 从bit0开始，按windowDays天一个窗口滑动，统计从最新窗口起连续达标(>=minDays)的窗口数
+func (c CheckinRecord) streakByWindow(windowDays, minDays int) int {
+	streak := 0
+	for start := 0; start < 64; start += windowDays {
+		end := start + windowDays
+		if end > 64 {
+			end = 64
+		}
+		count := 0
+		for day := start; day < end; day++ {
+			if c.IsCheckedDay(day) {
+				count++
+			}
+		}
+		if count < minDays {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// AggregateByFrequency 按指定频率把位图切成若干桶，返回每个桶内的打卡天数（桶0最新）
+func (c CheckinRecord) AggregateByFrequency(freq Frequency) []int {
+	windowDays := windowDaysOf(freq)
+	var result []int
+	for start := 0; start < 64; start += windowDays {
+		end := start + windowDays
+		if end > 64 {
+			end = 64
+		}
+		count := 0
+		for day := start; day < end; day++ {
+			if c.IsCheckedDay(day) {
+				count++
+			}
+		}
+		result = append(result, count)
+	}
+	return result
+}
+
+// calendarMonthBucket 描述一个自然月窗口在位图里对应的起止位
+type calendarMonthBucket struct {
+	start int // 起始位（含），0就是anchor当天
+	days  int // 这个月窗口占用的天数（位数）
+}
+
+// calendarMonthBuckets 以anchor为锚点（位0对应anchor这一天），按自然月切分[0,maxBits)区间。
+// 第一个桶是anchor所在月里从月初到anchor当天这一段（可能不满一个月，例如anchor是3月15号，
+// 第一个桶就只有15天），后面每个桶都是完整的自然月（28~31天不等），最后一个桶如果超出
+// maxBits会被截断。CheckinRecord/CheckinRecordN 的 MonthlyStreakFromAnchor 都基于这个切分
+func calendarMonthBuckets(anchor time.Time, maxBits int) []calendarMonthBucket {
+	var buckets []calendarMonthBucket
+	year, month, day := anchor.Date()
+
+	start := 0
+	days := day
+	for start < maxBits {
+		if start+days > maxBits {
+			days = maxBits - start
+		}
+		buckets = append(buckets, calendarMonthBucket{start: start, days: days})
+		start += days
+		if days == 0 {
+			break
+		}
+
+		month--
+		if month < time.January {
+			month = time.December
+			year--
+		}
+		days = daysInMonth(year, month)
+	}
+	return buckets
+}
+
+// daysInMonth 返回指定年月的天数（28~31），用下个月第0天（即上个月最后一天）取得
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,304 @@
+package checkin
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"time"
+)
+
+// wordBits 每个 uint64 word 能容纳的天数
+const wordBits = 64
+
+// CheckinRecordN 打卡记录（支持超过64天的窗口，使用 []uint64 存储）
+// words[0] 的最低位（bit 0）表示今天，依次向高位、向后续 word 推移，
+// 这样 ContinuousDays/TotalDaysInPeriod 等语义和 CheckinRecord 完全一致，只是窗口更大
+type CheckinRecordN struct {
+	words  []uint64
+	window int // 窗口天数，例如365或730
+}
+
+// NewCheckinRecordN 创建指定窗口天数的空打卡记录
+func NewCheckinRecordN(window int) CheckinRecordN {
+	return CheckinRecordN{
+		words:  make([]uint64, (window+wordBits-1)/wordBits),
+		window: window,
+	}
+}
+
+// FromInt64ToN 从已有的 int64 打卡记录（如 CheckinRecord.ToInt64()）迁移到指定窗口的 CheckinRecordN，
+// 原记录的64天历史原样放进窗口的前64天，其余天数保持未打卡
+func FromInt64ToN(value int64, window int) CheckinRecordN {
+	c := NewCheckinRecordN(window)
+	if len(c.words) > 0 {
+		c.words[0] = uint64(value)
+	}
+	return c
+}
+
+// Window 返回记录的窗口天数
+func (c CheckinRecordN) Window() int {
+	return c.window
+}
+
+// ToBytes 把记录编码成小端字节序列，每个 word 占8字节，words[0]（含bit 0）在最前面。
+// 用于落地到 VARBINARY(N) 列，N = len(words)*8，见文件末尾的存储建议
+func (c CheckinRecordN) ToBytes() []byte {
+	buf := make([]byte, len(c.words)*8)
+	for i, w := range c.words {
+		binary.LittleEndian.PutUint64(buf[i*8:], w)
+	}
+	return buf
+}
+
+// FromBytesN 是 ToBytes 的逆过程：按 window 算出需要的 word 数，从 data 里小端解码，
+// data 不足的尾部按0补齐（通常发生在 window 不是8的整数倍、VARBINARY 列没有填满最后一个word时）
+func FromBytesN(data []byte, window int) CheckinRecordN {
+	c := NewCheckinRecordN(window)
+	for i := range c.words {
+		off := i * 8
+		if off >= len(data) {
+			break
+		}
+		end := off + 8
+		if end > len(data) {
+			var tail [8]byte
+			copy(tail[:], data[off:])
+			c.words[i] = binary.LittleEndian.Uint64(tail[:])
+			break
+		}
+		c.words[i] = binary.LittleEndian.Uint64(data[off:end])
+	}
+	return c
+}
+
+// Checkin 打卡（设置今天的位为1）
+func (c CheckinRecordN) Checkin() CheckinRecordN {
+	return c.CheckinDay(0)
+}
+
+// CheckinDay 打卡指定天（0=今天，1=昨天，2=前天...）
+func (c CheckinRecordN) CheckinDay(day int) CheckinRecordN {
+	if day < 0 || day >= c.window {
+		return c
+	}
+	words := append([]uint64(nil), c.words...)
+	words[day/wordBits] |= 1 << uint(day%wordBits)
+	return CheckinRecordN{words: words, window: c.window}
+}
+
+// IsCheckedToday 今天是否打卡
+func (c CheckinRecordN) IsCheckedToday() bool {
+	return c.IsCheckedDay(0)
+}
+
+// IsCheckedDay 指定天是否打卡（0=今天，1=昨天，2=前天...）
+func (c CheckinRecordN) IsCheckedDay(day int) bool {
+	if day < 0 || day >= c.window {
+		return false
+	}
+	return c.words[day/wordBits]&(1<<uint(day%wordBits)) != 0
+}
+
+// ContinuousDays 获取从今天开始的连续打卡天数
+// 按word遍历：全1的word直接加64继续，否则加上该word里从低位数的连续1的个数后停止
+func (c CheckinRecordN) ContinuousDays() int {
+	count := 0
+	for _, w := range c.words {
+		if w == ^uint64(0) {
+			count += wordBits
+			continue
+		}
+		count += bits.TrailingZeros64(^w)
+		break
+	}
+	if count > c.window {
+		count = c.window
+	}
+	return count
+}
+
+// TotalDaysInPeriod 获取最近days天的总打卡天数，用 popcount 逐word统计，末尾不足一个word的部分做掩码
+func (c CheckinRecordN) TotalDaysInPeriod(days int) int {
+	if days <= 0 || days > c.window {
+		days = c.window
+	}
+
+	count := 0
+	remaining := days
+	for _, w := range c.words {
+		if remaining <= 0 {
+			break
+		}
+		if remaining >= wordBits {
+			count += bits.OnesCount64(w)
+			remaining -= wordBits
+			continue
+		}
+		mask := uint64(1)<<uint(remaining) - 1
+		count += bits.OnesCount64(w & mask)
+		remaining = 0
+	}
+	return count
+}
+
+// MaxContinuousDays 获取历史最大连续打卡天数：按word做游程扫描，carry记录跨word边界的连续段
+func (c CheckinRecordN) MaxContinuousDays() int {
+	maxCount, current := 0, 0
+	for i, w := range c.words {
+		if i == len(c.words)-1 && c.window%wordBits != 0 {
+			w &= uint64(1)<<uint(c.window%wordBits) - 1 // 窗口之外的尾部位不计入
+		}
+		if w == ^uint64(0) {
+			current += wordBits
+			if current > maxCount {
+				maxCount = current
+			}
+			continue
+		}
+		for i := 0; i < wordBits; i++ {
+			if w&(1<<uint(i)) != 0 {
+				current++
+				if current > maxCount {
+					maxCount = current
+				}
+			} else {
+				current = 0
+			}
+		}
+	}
+	return maxCount
+}
+
+// ShiftDay 时间推移一天（整体左移一位）
+func (c CheckinRecordN) ShiftDay() CheckinRecordN {
+	return c.shiftBy(1)
+}
+
+// shiftBy 整体左移n位，表示时间推移了n天；n超过窗口时记录清零
+func (c CheckinRecordN) shiftBy(n int) CheckinRecordN {
+	if n <= 0 {
+		return c
+	}
+	if n >= c.window {
+		return NewCheckinRecordN(c.window)
+	}
+
+	words := make([]uint64, len(c.words))
+	wordShift := n / wordBits
+	bitShift := uint(n % wordBits)
+
+	for i := len(c.words) - 1; i >= 0; i-- {
+		srcIdx := i - wordShift
+		if srcIdx < 0 {
+			continue
+		}
+		var v uint64
+		v = c.words[srcIdx] << bitShift
+		if bitShift > 0 && srcIdx-1 >= 0 {
+			v |= c.words[srcIdx-1] >> (wordBits - bitShift)
+		}
+		words[i] = v
+	}
+	return CheckinRecordN{words: words, window: c.window}
+}
+
+// CheckinRate 获取最近days天的打卡率（0.0-1.0）
+func (c CheckinRecordN) CheckinRate(days int) float64 {
+	if days <= 0 {
+		return 0.0
+	}
+	total := c.TotalDaysInPeriod(days)
+	return float64(total) / float64(days)
+}
+
+// WeeklyStreak 从今天所在的这一周开始，连续多少周满足"当周至少minDaysPerWeek天打卡"
+func (c CheckinRecordN) WeeklyStreak(minDaysPerWeek int) int {
+	return c.streakByWindow(daysPerWeek, minDaysPerWeek)
+}
+
+// MonthlyStreak 从今天所在的这个月开始，连续多少个月满足"当月至少minDaysPerMonth天打卡"。
+// 这里的"月"是30天的近似滑动窗口，不对齐自然月边界；需要对齐自然月时用 MonthlyStreakFromAnchor
+func (c CheckinRecordN) MonthlyStreak(minDaysPerMonth int) int {
+	return c.streakByWindow(daysPerMonth, minDaysPerMonth)
+}
+
+// MonthlyStreakFromAnchor 从anchor（位图第0位对应的日历日期）所在的自然月开始往回数，
+// 返回连续多少个自然月满足"当月至少minDaysPerMonth天打卡"，窗口跨度用的是窗口更大的
+// CheckinRecordN，月份切分逻辑和 CheckinRecord.MonthlyStreakFromAnchor 共用
+// calendarMonthBuckets，只是上限换成 c.window
+func (c CheckinRecordN) MonthlyStreakFromAnchor(anchor time.Time, minDaysPerMonth int) int {
+	streak := 0
+	for _, b := range calendarMonthBuckets(anchor, c.window) {
+		count := 0
+		for day := b.start; day < b.start+b.days; day++ {
+			if c.IsCheckedDay(day) {
+				count++
+			}
+		}
+		if count < minDaysPerMonth {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// streakByWindow 从bit0开始，按windowDays天一个窗口滑动，统计从最新窗口起连续达标(>=minDays)的窗口数
+func (c CheckinRecordN) streakByWindow(windowDays, minDays int) int {
+	streak := 0
+	for start := 0; start < c.window; start += windowDays {
+		end := start + windowDays
+		if end > c.window {
+			end = c.window
+		}
+		count := 0
+		for day := start; day < end; day++ {
+			if c.IsCheckedDay(day) {
+				count++
+			}
+		}
+		if count < minDays {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// AggregateByFrequency 按指定频率把位图切成若干桶，返回每个桶内的打卡天数（桶0最新）
+func (c CheckinRecordN) AggregateByFrequency(freq Frequency) []int {
+	windowDays := windowDaysOf(freq)
+	var result []int
+	for start := 0; start < c.window; start += windowDays {
+		end := start + windowDays
+		if end > c.window {
+			end = c.window
+		}
+		count := 0
+		for day := start; day < end; day++ {
+			if c.IsCheckedDay(day) {
+				count++
+			}
+		}
+		result = append(result, count)
+	}
+	return result
+}
+
+// ==================== 存储建议 ====================
+//
+// CheckinRecordN 的窗口可以远超64位，BIGINT放不下，持久化时二选一：
+//
+// 方案A：VARBINARY(N)，N = len(words)*8（即 ceil(window/8/8)*8，按word对齐），
+// 用 ToBytes()/FromBytesN(data, window) 编解码，整条记录一列搞定，和 CheckinRecord 用法最接近：
+//
+//	CREATE TABLE user_checkin_ext (
+//	    user_id BIGINT PRIMARY KEY COMMENT '用户ID',
+//	    record VARBINARY(96) NOT NULL DEFAULT '' COMMENT '打卡记录，ToBytes()/FromBytesN()编解码，对应window=730天',
+//	    anchor_date DATE NOT NULL COMMENT '第0位对应的日历日期（惰性移位锚点）',
+//	    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间'
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户打卡记录表（扩展窗口）';
+//
+// 方案B：多个 BIGINT 列（record_0, record_1, ...），每列对应 words[i]，适合窗口是64的整数倍、
+// 还想继续用 BIGINT 级别的索引/位运算（如 MySQL 的 record_0 & 1073741823 这类表达式）的场景；
+// 缺点是窗口大小变了要跟着改表结构，不如 VARBINARY 灵活
@@ -0,0 +1,99 @@
+package checkin
+
+import "testing"
+
+func TestCheckinRecordN_Basic(t *testing.T) {
+	record := NewCheckinRecordN(365)
+	if record.ContinuousDays() != 0 {
+		t.Errorf("新记录连续打卡天数应该是0，实际为 %d", record.ContinuousDays())
+	}
+
+	record = record.Checkin()
+	if !record.IsCheckedToday() {
+		t.Error("今天应该已打卡")
+	}
+	if record.ContinuousDays() != 1 {
+		t.Errorf("连续打卡天数应该是1，实际为 %d", record.ContinuousDays())
+	}
+}
+
+func TestCheckinRecordN_ContinuousDaysAcrossWords(t *testing.T) {
+	record := NewCheckinRecordN(200)
+	for day := 0; day < 130; day++ {
+		record = record.CheckinDay(day)
+	}
+
+	if got := record.ContinuousDays(); got != 130 {
+		t.Errorf("ContinuousDays() = %d, want 130（跨越多个word）", got)
+	}
+}
+
+func TestCheckinRecordN_TotalDaysInPeriod(t *testing.T) {
+	record := NewCheckinRecordN(100)
+	for day := 0; day < 100; day += 2 {
+		record = record.CheckinDay(day)
+	}
+
+	if got := record.TotalDaysInPeriod(100); got != 50 {
+		t.Errorf("TotalDaysInPeriod(100) = %d, want 50", got)
+	}
+	if got := record.TotalDaysInPeriod(10); got != 5 {
+		t.Errorf("TotalDaysInPeriod(10) = %d, want 5", got)
+	}
+}
+
+func TestCheckinRecordN_MaxContinuousDays(t *testing.T) {
+	record := NewCheckinRecordN(150)
+	for day := 0; day < 70; day++ {
+		record = record.CheckinDay(day)
+	}
+	for day := 80; day < 90; day++ {
+		record = record.CheckinDay(day)
+	}
+
+	if got := record.MaxContinuousDays(); got != 70 {
+		t.Errorf("MaxContinuousDays() = %d, want 70", got)
+	}
+}
+
+func TestCheckinRecordN_ShiftBy(t *testing.T) {
+	record := NewCheckinRecordN(128)
+	record = record.Checkin()
+
+	record = record.shiftBy(70)
+	if record.IsCheckedToday() {
+		t.Error("推移70天后今天不应该有打卡记录")
+	}
+	if !record.IsCheckedDay(70) {
+		t.Error("推移70天后，原先的今天应该变成第70天前")
+	}
+}
+
+func TestCheckinRecordN_FromInt64ToN(t *testing.T) {
+	record := FromInt64ToN(0b111, 365)
+	if record.ContinuousDays() != 3 {
+		t.Errorf("迁移后连续打卡天数应该是3，实际为 %d", record.ContinuousDays())
+	}
+	if record.Window() != 365 {
+		t.Errorf("Window() = %d, want 365", record.Window())
+	}
+}
+
+func TestCheckinRecordN_BytesRoundTrip(t *testing.T) {
+	record := NewCheckinRecordN(200)
+	for day := 0; day < 130; day += 3 {
+		record = record.CheckinDay(day)
+	}
+
+	data := record.ToBytes()
+	if len(data) != 32 { // 200天按64位一个word向上取整是4个word，4*8=32字节
+		t.Errorf("ToBytes() 长度 = %d, want 32", len(data))
+	}
+
+	got := FromBytesN(data, 200)
+	for day := 0; day < 130; day++ {
+		if got.IsCheckedDay(day) != record.IsCheckedDay(day) {
+			t.Errorf("第%d天往返后不一致: got=%v, want=%v", day, got.IsCheckedDay(day), record.IsCheckedDay(day))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package checkin
 
 import (
 	"testing"
+	"time"
 )
 
 func TestCheckinRecord_Basic(t *testing.T) {
@@ -111,6 +112,22 @@ func TestCheckinRecord_ShiftDay(t *testing.T) {
 	}
 }
 
+func TestCheckinRecord_ShiftBy(t *testing.T) {
+	record := CheckinRecord(0b1)
+
+	if got := record.shiftBy(0); got != record {
+		t.Errorf("shiftBy(0) 应该原样返回，实际为 %b", got)
+	}
+
+	if got := record.shiftBy(2); got != 0b100 {
+		t.Errorf("shiftBy(2) = %b, want %b", got, 0b100)
+	}
+
+	if got := record.shiftBy(64); got != 0 {
+		t.Errorf("shiftBy(64) 应该清零，实际为 %b", got)
+	}
+}
+
 func TestCheckinRecord_String(t *testing.T) {
 	// 连续3天打卡
 	record := CheckinRecord(0b111)
@@ -181,6 +198,56 @@ func TestCheckinRecord_CheckinRate(t *testing.T) {
 	}
 }
 
+func TestCheckinRecord_WeeklyStreak(t *testing.T) {
+	// 最近两周每天都打卡，第三周只打了1天
+	var record CheckinRecord
+	for day := 0; day < 14; day++ {
+		record = record.CheckinDay(day)
+	}
+	record = record.CheckinDay(14)
+
+	if got := record.WeeklyStreak(3); got != 2 {
+		t.Errorf("WeeklyStreak(3) = %d, want 2", got)
+	}
+}
+
+func TestCheckinRecord_MonthlyStreakFromAnchor(t *testing.T) {
+	// anchor定在3月31号（2024是闰年）：第一个桶正好是整个3月（31天），
+	// 第二个桶是整个2月（闰年29天），64位窗口只够再装下4天的1月，装不满一整个月
+	anchor := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	var record CheckinRecord
+	for day := 0; day < 64; day++ {
+		record = record.CheckinDay(day)
+	}
+
+	if got := record.MonthlyStreakFromAnchor(anchor, 20); got != 2 {
+		t.Errorf("MonthlyStreakFromAnchor() = %d, want 2（64位窗口只够装下完整的3月和2月）", got)
+	}
+
+	// 2月只打卡10天，不满足minDaysPerMonth=20，streak应该在第二个月就断掉
+	record = 0
+	for day := 0; day < 31; day++ { // 3月全打
+		record = record.CheckinDay(day)
+	}
+	for day := 31; day < 41; day++ { // 2月只打10天
+		record = record.CheckinDay(day)
+	}
+	if got := record.MonthlyStreakFromAnchor(anchor, 20); got != 1 {
+		t.Errorf("MonthlyStreakFromAnchor() = %d, want 1（2月只打卡10天，不满20天）", got)
+	}
+}
+
+func TestCheckinRecord_AggregateByFrequency(t *testing.T) {
+	// 最近7天全部打卡
+	record := CheckinRecord(0b1111111)
+
+	buckets := record.AggregateByFrequency(Weekly)
+	if len(buckets) == 0 || buckets[0] != 7 {
+		t.Errorf("AggregateByFrequency(Weekly)[0] = %v, want 7", buckets)
+	}
+}
+
 // 基准测试
 func BenchmarkCheckinRecord_Checkin(b *testing.B) {
 	record := NewCheckinRecord()
@@ -10,102 +10,89 @@ import (
 )
 
 // ==================== MySQL 示例 ====================
-//⏺ 每天0点必须执行这个 SQL：
-//UPDATE user_checkin SET record = record << 1
-//这条 SQL 会把所有用户的记录整体左移一位，让"今天"变成"昨天"。
+//⏺ DailyShiftTask 不再是必需的：AnchorDate 惰性移位机制下，
+//即使定时任务漏跑、跑了两次，或者服务器与用户时区不一致，位图也不会错位。
+//DailyShiftTask 仅作为可选的存储压缩手段保留（见下）。
 
 // UserCheckinModel 用户打卡数据模型
 type UserCheckinModel struct {
-	UserID    int64     `db:"user_id"`
-	Record    int64     `db:"record"` // 打卡记录（BIGINT类型）
-	UpdatedAt time.Time `db:"updated_at"`
+	UserID     int64     `db:"user_id"`
+	Record     int64     `db:"record"`      // 打卡记录（BIGINT类型）
+	AnchorDate time.Time `db:"anchor_date"` // 第0位对应的日历日期（惰性移位的锚点）
+	UpdatedAt  time.Time `db:"updated_at"`
 }
 
-// CheckinService 打卡服务
+// CheckinService 打卡服务，业务逻辑只依赖 Store 接口，不关心具体存储后端
 type CheckinService struct {
-	db *sql.DB
+	store Store
 }
 
-// NewCheckinService 创建打卡服务
-func NewCheckinService(db *sql.DB) *CheckinService {
-	return &CheckinService{db: db}
+// NewCheckinService 基于任意 Store 实现创建打卡服务
+func NewCheckinService(store Store) *CheckinService {
+	return &CheckinService{store: store}
 }
 
-// UserCheckin 用户打卡
-func (s *CheckinService) UserCheckin(ctx context.Context, userID int64) error {
-	// 1. 从数据库读取记录
-	var dbValue int64
-	err := s.db.QueryRowContext(ctx,
-		"SELECT record FROM user_checkin WHERE user_id = ?",
-		userID,
-	).Scan(&dbValue)
-
-	if err == sql.ErrNoRows {
-		// 首次打卡，创建记录
-		dbValue = 0
-	} else if err != nil {
-		return fmt.Errorf("查询失败: %w", err)
-	}
+// GetDiffDays 计算两个时间之间相差的自然日天数（t2 - t1），只比较年月日
+func GetDiffDays(t1, t2 time.Time) int {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	date1 := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	date2 := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(date2.Sub(date1).Hours() / 24)
+}
 
-	// 2. 转换为 CheckinRecord 类型
-	record := FromInt64(dbValue)
+// loadAndShift 读取用户记录，并按 AnchorDate 与“今天”（loc 时区下）之间的天数差惰性移位
+// loc 为 nil 时使用 time.Local。不存在记录时返回全零记录，锚点即为今天
+func (s *CheckinService) loadAndShift(ctx context.Context, userID int64, loc *time.Location) (CheckinRecord, time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	today := time.Now().In(loc)
 
-	// 3. 检查今天是否已打卡
-	if record.IsCheckedToday() {
-		return fmt.Errorf("今天已经打卡过了")
+	value, anchor, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("查询失败: %w", err)
+	}
+	if anchor.IsZero() {
+		return value, today, nil
 	}
 
-	// 4. 打卡
-	record = record.Checkin()
+	delta := GetDiffDays(anchor, today)
+	return value.shiftBy(delta), today, nil
+}
 
-	// 5. 保存回数据库
-	newValue := record.ToInt64()
+// UserCheckin 用户打卡，返回 CheckinResult 以便调用方区分"今天已经打过卡的幂等调用"
+// 和"今天第一次打卡"。支持 AtomicCheckinStore 的后端（如 MySQLStore）会在行锁保护下
+// 一次性完成"惰性移位+判断+写入"，避免两次并发打卡互相覆盖
+func (s *CheckinService) UserCheckin(ctx context.Context, userID int64, loc *time.Location) (CheckinResult, error) {
+	if as, ok := s.store.(AtomicCheckinStore); ok {
+		return as.CheckinAtomic(ctx, userID, loc)
+	}
 
-	if dbValue == 0 {
-		// 插入新记录
-		_, err = s.db.ExecContext(ctx,
-			"INSERT INTO user_checkin (user_id, record) VALUES (?, ?)",
-			userID, newValue,
-		)
-	} else {
-		// 更新现有记录
-		_, err = s.db.ExecContext(ctx,
-			"UPDATE user_checkin SET record = ?, updated_at = NOW() WHERE user_id = ?",
-			newValue, userID,
-		)
+	// 退回到非原子路径：读取、判断、写入之间没有锁保护，两次并发调用可能互相覆盖
+	record, today, err := s.loadAndShift(ctx, userID, loc)
+	if err != nil {
+		return CheckinResult{}, err
 	}
 
-	return err
-}
+	if record.IsCheckedToday() {
+		return CheckinResult{AlreadyCheckedToday: true, NewRecord: record}, nil
+	}
 
-// GetUserCheckinStats 获取用户打卡统计
-func (s *CheckinService) GetUserCheckinStats(ctx context.Context, userID int64) (map[string]interface{}, error) {
-	// 从数据库读取
-	var dbValue int64
-	err := s.db.QueryRowContext(ctx,
-		"SELECT record FROM user_checkin WHERE user_id = ?",
-		userID,
-	).Scan(&dbValue)
-
-	if err == sql.ErrNoRows {
-		// 没有记录
-		return map[string]interface{}{
-			"continuous_days": 0,
-			"total_7days":     0,
-			"total_30days":    0,
-			"max_continuous":  0,
-			"rate_7days":      0.0,
-			"recent_7days":    "✗✗✗✗✗✗✗",
-		}, nil
+	record = record.Checkin()
+	if err := s.store.Set(ctx, userID, record, today); err != nil {
+		return CheckinResult{}, err
 	}
+	return CheckinResult{AlreadyCheckedToday: false, NewRecord: record}, nil
+}
 
+// GetUserCheckinStats 获取用户打卡统计
+func (s *CheckinService) GetUserCheckinStats(ctx context.Context, userID int64, loc *time.Location) (map[string]interface{}, error) {
+	record, today, err := s.loadAndShift(ctx, userID, loc)
 	if err != nil {
 		return nil, err
 	}
 
-	// 转换并计算统计
-	record := FromInt64(dbValue)
-
 	return map[string]interface{}{
 		"continuous_days": record.ContinuousDays(),
 		"total_7days":     record.TotalDaysInPeriod(7),
@@ -114,100 +101,63 @@ func (s *CheckinService) GetUserCheckinStats(ctx context.Context, userID int64)
 		"rate_7days":      record.CheckinRate(7),
 		"recent_7days":    record.StringWithDays(7),
 		"bitmap_7days":    record.GetDaysBitmap(7),
+		"weekly_streak":   record.WeeklyStreak(3),                    // 连续多少周每周至少打卡3天
+		"monthly_streak":  record.MonthlyStreakFromAnchor(today, 20), // 连续多少个自然月当月至少打卡20天
+		"weekly_buckets":  record.AggregateByFrequency(Weekly),
+		"monthly_buckets": record.AggregateByFrequency(Monthly),
 	}, nil
 }
 
-// IsCheckedToday 检查今天是否已打卡
-func (s *CheckinService) IsCheckedToday(ctx context.Context, userID int64) (bool, error) {
-	var dbValue int64
-	err := s.db.QueryRowContext(ctx,
-		"SELECT record FROM user_checkin WHERE user_id = ?",
-		userID,
-	).Scan(&dbValue)
-
-	if err == sql.ErrNoRows {
-		return false, nil
+// IsCheckedToday 检查今天是否已打卡。支持 FastCheckStore 的后端（如 RedisStore）
+// 会直接用原生的 GETBIT 回答，不需要先把整条记录读回来再在Go里惰性移位
+func (s *CheckinService) IsCheckedToday(ctx context.Context, userID int64, loc *time.Location) (bool, error) {
+	if fs, ok := s.store.(FastCheckStore); ok {
+		return fs.IsCheckedToday(ctx, userID, loc)
 	}
 
+	record, _, err := s.loadAndShift(ctx, userID, loc)
 	if err != nil {
 		return false, err
 	}
-
-	record := FromInt64(dbValue)
 	return record.IsCheckedToday(), nil
 }
 
-// DailyShiftTask 定时任务：每天0点执行，推移所有记录
+// DailyShiftTask 可选的存储压缩任务：将所有 record 左移1位、anchor_date 前移一天
+// 惰性移位使得本任务不再是正确性所必需的，只是用来防止 record 无限期不被移位而错过压缩的机会；
+// 可以按任意节奏（甚至完全不）运行，不会影响 ContinuousDays 等统计结果
 func (s *CheckinService) DailyShiftTask(ctx context.Context) error {
-	// 方式1：直接SQL操作（最高效）
-	// 将所有 record 左移1位（相当于 record = record << 1）
-	_, err := s.db.ExecContext(ctx,
-		"UPDATE user_checkin SET record = record << 1",
-	)
-	return err
-
-	// 方式2：逐条处理（如果需要额外逻辑）
-	// rows, err := s.db.QueryContext(ctx, "SELECT user_id, record FROM user_checkin")
-	// ... 循环处理每条记录
+	return s.store.BatchShift(ctx)
 }
 
-// GetCheckinLeaderboard 获取连续打卡排行榜 TOP N
-func (s *CheckinService) GetCheckinLeaderboard(ctx context.Context, limit int) ([]map[string]interface{}, error) {
-	// 从数据库读取记录
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT user_id, record FROM user_checkin ORDER BY record DESC LIMIT ?",
-		limit*2, // 多取一些，因为需要重新计算连续天数排序
-	)
+// GetCheckinLeaderboard 获取排行榜 TOP N。metric 支持的后端（如 MySQLStore）会直接在存储层
+// 按对应的派生列排序返回——同时只挑出 anchor_date = 今天 的行，断签的用户不会顶着
+// 断签前的派生列旧值混进榜单（见 MySQLStore.TopNByMetric）。不支持的后端退回到
+// Store.TopN（按连续天数）加进程内排序
+func (s *CheckinService) GetCheckinLeaderboard(ctx context.Context, limit int, metric LeaderboardMetric) ([]map[string]interface{}, error) {
+	var it RankIterator
+	var err error
+	if ms, ok := s.store.(MetricStore); ok {
+		it, err = ms.TopNByMetric(ctx, limit, metric)
+	} else {
+		it, err = s.store.TopN(ctx, limit)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	// 计算连续打卡天数并排序
-	type userRank struct {
-		UserID         int64
-		Record         int64
-		ContinuousDays int
-	}
-
-	var ranks []userRank
-	for rows.Next() {
-		var r userRank
-		if err := rows.Scan(&r.UserID, &r.Record); err != nil {
-			return nil, err
-		}
-
-		// 转换并计算连续天数
-		record := FromInt64(r.Record)
-		r.ContinuousDays = record.ContinuousDays()
-
-		ranks = append(ranks, r)
-	}
-
-	// 按连续天数排序（简单冒泡排序，生产环境建议用标准库sort）
-	for i := 0; i < len(ranks)-1; i++ {
-		for j := 0; j < len(ranks)-i-1; j++ {
-			if ranks[j].ContinuousDays < ranks[j+1].ContinuousDays {
-				ranks[j], ranks[j+1] = ranks[j+1], ranks[j]
-			}
-		}
-	}
-
-	// 取前N名
-	if len(ranks) > limit {
-		ranks = ranks[:limit]
-	}
-
-	// 转换为返回格式
-	result := make([]map[string]interface{}, len(ranks))
-	for i, r := range ranks {
-		record := FromInt64(r.Record)
-		result[i] = map[string]interface{}{
-			"user_id":         r.UserID,
-			"continuous_days": r.ContinuousDays,
+	defer it.Close()
+
+	var result []map[string]interface{}
+	for it.Next(ctx) {
+		record := it.Record()
+		result = append(result, map[string]interface{}{
+			"user_id":         it.UserID(),
+			"continuous_days": record.ContinuousDays(),
 			"total_7days":     record.TotalDaysInPeriod(7),
 			"recent":          record.StringWithDays(7),
-		}
+		})
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -225,20 +175,24 @@ func Example_DatabaseUsage() {
 	}
 	defer db.Close()
 
-	// 2. 创建打卡服务
-	service := NewCheckinService(db)
+	// 2. 创建打卡服务（这里选用 MySQL 存储后端，也可以换成 LevelDB 或 Redis）
+	service := NewCheckinService(NewMySQLStore(db))
 	ctx := context.Background()
 
-	// 3. 用户打卡
+	// 3. 用户打卡（按用户所在时区的“今天”判断，nil 表示使用服务器本地时区）
 	userID := int64(12345)
-	if err := service.UserCheckin(ctx, userID); err != nil {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	result, err := service.UserCheckin(ctx, userID, loc)
+	if err != nil {
 		fmt.Printf("打卡失败: %v\n", err)
+	} else if result.AlreadyCheckedToday {
+		fmt.Println("今天已经打过卡了")
 	} else {
 		fmt.Println("打卡成功！")
 	}
 
 	// 4. 获取打卡统计
-	stats, err := service.GetUserCheckinStats(ctx, userID)
+	stats, err := service.GetUserCheckinStats(ctx, userID, loc)
 	if err != nil {
 		fmt.Printf("查询失败: %v\n", err)
 		return
@@ -248,8 +202,8 @@ func Example_DatabaseUsage() {
 	fmt.Printf("最近7天: %s\n", stats["recent_7days"])
 	fmt.Printf("打卡率: %.1f%%\n", stats["rate_7days"].(float64)*100)
 
-	// 5. 获取排行榜
-	leaderboard, _ := service.GetCheckinLeaderboard(ctx, 10)
+	// 5. 获取排行榜（MetricContinuousDays：按连续打卡天数排序）
+	leaderboard, _ := service.GetCheckinLeaderboard(ctx, 10, MetricContinuousDays)
 	fmt.Println("\n=== 连续打卡排行榜 ===")
 	for i, user := range leaderboard {
 		fmt.Printf("%d. 用户%d - 连续%d天 %s\n",
@@ -265,25 +219,47 @@ func Example_DatabaseUsage() {
 
 const (
 	// 建表语句
+	// continuous_days 由应用层（MySQLStore.Set / Maintainer）维护，total_30days 是MySQL生成列，
+	// 两者都建了索引。但两列都是相对 anchor_date 算出来的，只有 anchor_date = 今天 的那一刻才
+	// 等于实时值——查询时必须带上 anchor_date = 今天 这个过滤条件（见 TopNByMetric），
+	// 不能直接信任全表 ORDER BY 这两列的结果，否则断签很久的用户会一直顶着断签前的峰值排行
 	CreateTableSQL = `
 CREATE TABLE user_checkin (
     user_id BIGINT PRIMARY KEY COMMENT '用户ID',
     record BIGINT NOT NULL DEFAULT 0 COMMENT '打卡记录',
+    anchor_date DATE NOT NULL COMMENT '第0位对应的日历日期（惰性移位锚点）',
+    continuous_days INT NOT NULL DEFAULT 0 COMMENT '连续打卡天数，仅在 anchor_date = 今天 时等于实时值，应用层在写入record时一并维护',
+    total_30days INT GENERATED ALWAYS AS (BIT_COUNT(record & 1073741823)) STORED COMMENT '最近30天打卡天数，仅在 anchor_date = 今天 时等于实时值',
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
-    INDEX idx_record (record) COMMENT '排行榜查询索引'
+    INDEX idx_continuous_days (continuous_days) COMMENT '连续打卡排行榜索引，配合 anchor_date = 今天 使用',
+    INDEX idx_total_30days (total_30days) COMMENT '30天打卡排行榜索引，配合 anchor_date = 今天 使用'
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户打卡记录表';
 `
 
-	// 用户打卡
+	// 为已有的 user_checkin 表补上 continuous_days / total_30days 两列；
+	// 加完列后需要跑一次 Maintainer.BackfillContinuousDays 回填 continuous_days 的存量数据
+	MigrateAddDerivedColumnsSQL = `
+ALTER TABLE user_checkin
+    ADD COLUMN continuous_days INT NOT NULL DEFAULT 0 COMMENT '连续打卡天数，仅在 anchor_date = 今天 时等于实时值，应用层在写入record时一并维护',
+    ADD COLUMN total_30days INT GENERATED ALWAYS AS (BIT_COUNT(record & 1073741823)) STORED COMMENT '最近30天打卡天数，仅在 anchor_date = 今天 时等于实时值',
+    ADD INDEX idx_continuous_days (continuous_days),
+    ADD INDEX idx_total_30days (total_30days);
+`
+
+	// 用户打卡写入语句的参考形态（anchor_date、continuous_days 由应用层算好后传入）。
+	// MySQLStore.CheckinAtomic 在事务里先 SELECT ... FOR UPDATE 锁住该行、算出惰性移位后的
+	// 新记录，再执行和这条SQL同样形态的写入，避免两次并发打卡互相覆盖
 	CheckinSQL = `
-INSERT INTO user_checkin (user_id, record) VALUES (?, 1) ON DUPLICATE KEY UPDATE record = record | 1
+INSERT INTO user_checkin (user_id, record, anchor_date, continuous_days) VALUES (?, 1, ?, 1)
+ON DUPLICATE KEY UPDATE record = ?, anchor_date = ?, continuous_days = ?
 `
 
-	// 查询打卡记录
-	GetRecordSQL = `SELECT record FROM user_checkin WHERE user_id = ?`
+	// 查询打卡记录（含锚点，供应用层计算惰性移位）
+	GetRecordSQL = `SELECT record, anchor_date FROM user_checkin WHERE user_id = ?`
 
-	// 每日推移（定时任务）
-	DailyShiftSQL = `UPDATE user_checkin SET record = record << 1
+	// 每日推移（可选的存储压缩任务，不再是正确性所必需）。移位后 bit0 必然是0，
+	// continuous_days 在同一条语句里直接置0，不需要再回填
+	DailyShiftSQL = `UPDATE user_checkin SET record = record << 1, anchor_date = DATE_ADD(anchor_date, INTERVAL 1 DAY), continuous_days = 0
 `
 	// 清理过期数据（可选，删除长期未打卡的用户）
 	CleanupSQL = `DELETE FROM user_checkin WHERE record = 0 AND updated_at < DATE_SUB(NOW(), INTERVAL 90 DAY)
@@ -298,17 +274,26 @@ INSERT INTO user_checkin (user_id, record) VALUES (?, 1) ON DUPLICATE KEY UPDATE
 1. 创建数据库表：
    执行 CreateTableSQL
 
-2. 在代码中使用：
-   - 读取：SELECT record FROM user_checkin WHERE user_id = ?
-   - 转换：record := checkin.FromInt64(dbValue)
-   - 操作：record = record.Checkin()
-   - 保存：UPDATE user_checkin SET record = ? WHERE user_id = ?
+2. 用户打卡：
+   service.UserCheckin(ctx, userID, loc) 返回 CheckinResult{AlreadyCheckedToday, NewRecord}。
+   MySQLStore 在一个事务里 SELECT ... FOR UPDATE 锁住该用户这一行，锁内完成惰性移位、
+   判断今天是否已打卡、写入，杜绝两次并发打卡互相覆盖对方的结果
+
+3. 定时任务（可选，仅用于存储压缩，不再是正确性所必需）：
+   执行 DailyShiftSQL，将所有记录左移1位、anchor_date 前移一天，
+   continuous_days 在同一条语句里置0（移位后bit0必然是0）。
+   MySQLStore.BatchShift 内部用 GET_LOCK 加了咨询锁，同一时刻只有一个实例能真正执行移位
 
-3. 定时任务（每天0点）：
-   执行 DailyShiftSQL，将所有记录左移1位
+4. 排行榜：
+   GetCheckinLeaderboard(ctx, limit, metric) 直接按 continuous_days 或 total_30days
+   索引列排序取前N名，不需要多取数据再在Go里重排。两列都只在 anchor_date = 今天 时
+   才等于实时值，所以查询永远带着 anchor_date = 今天 这个过滤条件——断签的用户不会
+   顶着断签前的旧值混进榜单，但也意味着断签用户不会出现在结果里（而不是排在末尾、值为0）
 
-4. 重要提示：
+5. 重要提示：
    - 数据库字段必须用 BIGINT，不能用 INT
    - Go 代码中用 int64 类型接收和存储
    - 一次查询就能计算所有统计，不需要额外SQL
+   - 每个用户按自己的 anchor_date 和时区独立推移，互不影响、也不怕漏跑/重复跑定时任务
+   - 已有表升级请执行 MigrateAddDerivedColumnsSQL，再跑一次 BackfillContinuousDays
 */
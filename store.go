@@ -0,0 +1,74 @@
+package checkin
+
+import (
+	"context"
+	"time"
+)
+
+// Store 打卡记录的存储后端接口
+// CheckinService 的业务逻辑（惰性移位、连续天数等）只依赖这个接口，
+// 具体落在 MySQL、LevelDB 还是 Redis 由调用方选择实现
+type Store interface {
+	// Get 读取用户当前的打卡记录与锚点日期；用户不存在时返回零值记录、零值时间、nil错误
+	Get(ctx context.Context, userID int64) (CheckinRecord, time.Time, error)
+
+	// Set 写入用户的打卡记录与锚点日期（覆盖写）
+	Set(ctx context.Context, userID int64, value CheckinRecord, anchor time.Time) error
+
+	// BatchShift 对所有用户做一次整体移位（record<<1，anchor前移一天）
+	// 这是可选的存储压缩操作，不支持惰性移位时才需要依赖它
+	BatchShift(ctx context.Context) error
+
+	// TopN 返回按连续打卡天数排序的前N名游标，调用方遍历后需调用 Close
+	TopN(ctx context.Context, n int) (RankIterator, error)
+}
+
+// LeaderboardMetric 排行榜排序指标
+type LeaderboardMetric int
+
+const (
+	MetricContinuousDays LeaderboardMetric = iota // 按当前连续打卡天数排序
+	MetricTotal30Days                             // 按最近30天打卡天数排序
+)
+
+// CheckinResult 一次打卡调用的结果
+type CheckinResult struct {
+	AlreadyCheckedToday bool          // true表示今天已经打过卡，这次调用是幂等的重复打卡
+	NewRecord           CheckinRecord // 打卡（或幂等跳过）后的最新记录
+}
+
+// AtomicCheckinStore 可选接口：把"惰性移位 + 判断今天是否已打卡 + 写入"这一整套操作
+// 做成一次原子操作的后端实现它（通常依赖行锁），避免两次并发打卡互相覆盖对方的结果。
+// 不支持时，调用方退回到 Store.Get/Set 的非原子路径
+type AtomicCheckinStore interface {
+	CheckinAtomic(ctx context.Context, userID int64, loc *time.Location) (CheckinResult, error)
+}
+
+// MetricStore 可选接口：能够在存储层自己按指标排序返回排行榜的后端实现它
+// （通常依赖预先维护好的派生列，例如 MySQLStore 的 continuous_days/total_30days）。
+// 不支持时，调用方应退回到 Store.TopN 加内存排序
+type MetricStore interface {
+	TopNByMetric(ctx context.Context, n int, metric LeaderboardMetric) (RankIterator, error)
+}
+
+// FastCheckStore 可选接口：能用原生的 O(1)～O(n/64) 操作直接回答"今天是否已打卡"、
+// 不需要把整条记录读回来再在Go里做惰性移位的后端实现它（例如 RedisStore 基于 GETBIT）。
+// 不支持时，调用方退回到 Store.Get 加 CheckinService.loadAndShift 的通用路径
+type FastCheckStore interface {
+	IsCheckedToday(ctx context.Context, userID int64, loc *time.Location) (bool, error)
+}
+
+// RankIterator 排行榜遍历游标
+// 用法：for it.Next(ctx) { ... }；遍历结束或出错后调用 Close 释放资源
+type RankIterator interface {
+	// Next 前进到下一条记录，没有更多记录或出错时返回false
+	Next(ctx context.Context) bool
+	// UserID 当前记录的用户ID
+	UserID() int64
+	// Record 当前记录的打卡位图
+	Record() CheckinRecord
+	// Err 返回遍历过程中遇到的错误（如果有）
+	Err() error
+	// Close 释放游标持有的资源
+	Close() error
+}
@@ -0,0 +1,187 @@
+package checkin
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LevelDBStore 基于内嵌 LevelDB 的 Store 实现，适合不想依赖外部数据库的单进程部署
+// 每个用户一条记录，key 为 "user:<id>"，value 为 varint(record) + varint(anchor单位为Unix天)
+type LevelDBStore struct {
+	db *leveldb.DB
+}
+
+// NewLevelDBStore 打开（或创建）指定路径下的 LevelDB 存储
+func NewLevelDBStore(path string) (*LevelDBStore, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("打开leveldb失败: %w", err)
+	}
+	return &LevelDBStore{db: db}, nil
+}
+
+// Close 关闭底层 LevelDB 句柄
+func (l *LevelDBStore) Close() error {
+	return l.db.Close()
+}
+
+func userKey(userID int64) []byte {
+	return []byte(fmt.Sprintf("user:%d", userID))
+}
+
+// encodeRecord 将记录值和锚点（按Unix天数）编码为 value = varint(value) + varint(anchorDays)
+func encodeRecord(value CheckinRecord, anchor time.Time) []byte {
+	buf := make([]byte, binary.MaxVarintLen64*2)
+	n := binary.PutVarint(buf, value.ToInt64())
+	n += binary.PutVarint(buf[n:], anchorDays(anchor))
+	return buf[:n]
+}
+
+// decodeRecord 是 encodeRecord 的逆过程
+func decodeRecord(data []byte) (CheckinRecord, time.Time, error) {
+	value, n := binary.Varint(data)
+	if n <= 0 {
+		return 0, time.Time{}, fmt.Errorf("解码打卡记录失败: 数据损坏")
+	}
+	days, n2 := binary.Varint(data[n:])
+	if n2 <= 0 {
+		return 0, time.Time{}, fmt.Errorf("解码锚点日期失败: 数据损坏")
+	}
+	return FromInt64(value), daysToTime(days), nil
+}
+
+// anchorDays 取 t 在其自身时区下的日历日期，换算成 Unix 天数
+func anchorDays(t time.Time) int64 {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / int64(24*time.Hour/time.Second)
+}
+
+func daysToTime(days int64) time.Time {
+	return time.Unix(days*int64(24*time.Hour/time.Second), 0).UTC()
+}
+
+// Get 读取用户当前的打卡记录与锚点日期；用户不存在时返回零值
+func (l *LevelDBStore) Get(ctx context.Context, userID int64) (CheckinRecord, time.Time, error) {
+	data, err := l.db.Get(userKey(userID), nil)
+	if err == leveldb.ErrNotFound {
+		return NewCheckinRecord(), time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return decodeRecord(data)
+}
+
+// Set 写入用户的打卡记录与锚点日期
+func (l *LevelDBStore) Set(ctx context.Context, userID int64, value CheckinRecord, anchor time.Time) error {
+	return l.db.Put(userKey(userID), encodeRecord(value, anchor), nil)
+}
+
+// BatchShift 对所有用户做一次整体移位，纯粹的存储压缩操作
+func (l *LevelDBStore) BatchShift(ctx context.Context) error {
+	it := l.db.NewIterator(util.BytesPrefix([]byte("user:")), nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		value, anchor, err := decodeRecord(it.Value())
+		if err != nil {
+			return err
+		}
+		shifted := value.shiftBy(1)
+		newAnchor := anchor.AddDate(0, 0, 1)
+		key := append([]byte(nil), it.Key()...)
+		batch.Put(key, encodeRecord(shifted, newAnchor))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return l.db.Write(batch, nil)
+}
+
+// TopN 返回连续打卡天数前N名。LevelDB 的 key 按用户ID排序而非按分数排序，所以这里做一次
+// 全量扫描。每个用户的 anchor 各不相同，直接对存量 record 排序会让断签很久的用户顶着
+// 断签前的峰值常驻榜首（和 chunk0-5 里 MySQL 派行榜一开始踩的坑一样），所以这里不经
+// Recorder()（它不对外暴露anchor），而是直接解码拿到anchor，排序前先按各自的
+// GetDiffDays(anchor, today) 惰性移位到今天
+func (l *LevelDBStore) TopN(ctx context.Context, n int) (RankIterator, error) {
+	it := l.db.NewIterator(util.BytesPrefix([]byte("user:")), nil)
+	defer it.Release()
+
+	today := time.Now()
+	var entries []rankEntry
+	for it.Next() {
+		value, anchor, err := decodeRecord(it.Value())
+		if err != nil {
+			return nil, err
+		}
+		var userID int64
+		fmt.Sscanf(string(it.Key()), "user:%d", &userID)
+		if !anchor.IsZero() {
+			value = value.shiftBy(GetDiffDays(anchor, today))
+		}
+		entries = append(entries, rankEntry{userID: userID, record: value})
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	sortEntriesByContinuousDays(entries)
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return newSliceRankIterator(entries), nil
+}
+
+// Recorder 返回一个按 key（即用户ID的字典序）有序的全量扫描游标，
+// 供没有 MySQL 依赖的进程自行计算排行榜或其它聚合指标
+func (l *LevelDBStore) Recorder() RankIterator {
+	it := l.db.NewIterator(util.BytesPrefix([]byte("user:")), nil)
+	return &leveldbRecorder{it: it}
+}
+
+// leveldbRecorder 包装 LevelDB 原生迭代器，实现 RankIterator
+type leveldbRecorder struct {
+	it  iterator.Iterator
+	err error
+}
+
+func (r *leveldbRecorder) Next(ctx context.Context) bool {
+	if r.err != nil {
+		return false
+	}
+	return r.it.Next()
+}
+
+func (r *leveldbRecorder) UserID() int64 {
+	var id int64
+	fmt.Sscanf(string(r.it.Key()), "user:%d", &id)
+	return id
+}
+
+func (r *leveldbRecorder) Record() CheckinRecord {
+	value, _, err := decodeRecord(r.it.Value())
+	if err != nil {
+		r.err = err
+		return 0
+	}
+	return value
+}
+
+func (r *leveldbRecorder) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.it.Error()
+}
+
+func (r *leveldbRecorder) Close() error {
+	r.it.Release()
+	return nil
+}
@@ -0,0 +1,313 @@
+package checkin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MySQLStore 基于 MySQL 的 Store 实现（对应 CreateTableSQL 建的 user_checkin 表）
+// continuous_days 由本结构体在每次 Set/BatchShift 时一并维护，total_30days 是数据库生成列，
+// 两者都建有索引，排行榜查询因此不再需要把数据搬回 Go 进程里重排
+type MySQLStore struct {
+	db *sql.DB
+}
+
+// NewMySQLStore 创建 MySQL 存储后端
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+// Get 读取用户当前的打卡记录与锚点日期；用户不存在时返回零值
+func (m *MySQLStore) Get(ctx context.Context, userID int64) (CheckinRecord, time.Time, error) {
+	var dbValue int64
+	var anchor time.Time
+	err := m.db.QueryRowContext(ctx,
+		GetRecordSQL,
+		userID,
+	).Scan(&dbValue, &anchor)
+
+	if err == sql.ErrNoRows {
+		return NewCheckinRecord(), time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("查询失败: %w", err)
+	}
+
+	return FromInt64(dbValue), anchor, nil
+}
+
+// Set 写入用户的打卡记录与锚点日期，并在同一条语句里维护 continuous_days 派生列
+// （total_30days 是 STORED GENERATED 列，MySQL 自己会算，不需要在这里维护）
+func (m *MySQLStore) Set(ctx context.Context, userID int64, value CheckinRecord, anchor time.Time) error {
+	continuousDays := value.ContinuousDays()
+	_, err := m.db.ExecContext(ctx,
+		"INSERT INTO user_checkin (user_id, record, anchor_date, continuous_days) VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE record = ?, anchor_date = ?, continuous_days = ?, updated_at = NOW()",
+		userID, value.ToInt64(), anchor, continuousDays,
+		value.ToInt64(), anchor, continuousDays,
+	)
+	return err
+}
+
+// dailyShiftLockName 是 BatchShift 持有的 MySQL 咨询锁名字，防止定时任务漏跑导致的重复调度
+// 在同一张表上并发执行移位（那样会把某些用户多移一位）
+const dailyShiftLockName = "checkin_daily_shift"
+
+// ErrShiftAlreadyRunning 表示已经有一个 BatchShift 在执行，本次调用被跳过
+var ErrShiftAlreadyRunning = fmt.Errorf("daily shift already running")
+
+// BatchShift 对所有用户做一次整体移位（见 DailyShiftSQL）。用 GET_LOCK 包一层咨询锁，
+// 避免同一张表被并发移位两次。移位后 bit0 必然是0，DailyShiftSQL 在同一条语句里把
+// continuous_days 派生列置0，不需要再跑 Maintainer.BackfillContinuousDays
+//
+// GET_LOCK/RELEASE_LOCK 是按MySQL会话持有的，必须在同一条连接上获取和释放，
+// 所以这里从连接池里单独借出一条连接（sql.Conn）贯穿整个操作，而不是用 m.db 直接查询——
+// 后者每次调用都可能从池子里拿到不同的连接，会导致锁永远释放不掉
+func (m *MySQLStore) BatchShift(ctx context.Context) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var got int
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", dailyShiftLockName).Scan(&got); err != nil {
+		return fmt.Errorf("获取移位锁失败: %w", err)
+	}
+	if got != 1 {
+		return ErrShiftAlreadyRunning
+	}
+	defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", dailyShiftLockName)
+
+	_, err = conn.ExecContext(ctx, DailyShiftSQL)
+	return err
+}
+
+// CheckinAtomic 把惰性移位、判断今天是否已打卡、写入，在一个事务里用 SELECT ... FOR UPDATE
+// 锁住该用户这一行做完，避免两次并发打卡一个读到旧值、互相覆盖对方写入的结果
+func (m *MySQLStore) CheckinAtomic(ctx context.Context, userID int64, loc *time.Location) (CheckinResult, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	today := time.Now().In(loc)
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return CheckinResult{}, err
+	}
+	defer tx.Rollback()
+
+	var dbValue int64
+	var anchor time.Time
+	err = tx.QueryRowContext(ctx,
+		"SELECT record, anchor_date FROM user_checkin WHERE user_id = ? FOR UPDATE",
+		userID,
+	).Scan(&dbValue, &anchor)
+
+	var record CheckinRecord
+	switch err {
+	case sql.ErrNoRows:
+		record = NewCheckinRecord()
+	case nil:
+		record = FromInt64(dbValue).shiftBy(GetDiffDays(anchor, today))
+	default:
+		return CheckinResult{}, fmt.Errorf("查询失败: %w", err)
+	}
+
+	if record.IsCheckedToday() {
+		return CheckinResult{AlreadyCheckedToday: true, NewRecord: record}, tx.Commit()
+	}
+
+	record = record.Checkin()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO user_checkin (user_id, record, anchor_date, continuous_days) VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE record = ?, anchor_date = ?, continuous_days = ?",
+		userID, record.ToInt64(), today, record.ContinuousDays(),
+		record.ToInt64(), today, record.ContinuousDays(),
+	)
+	if err != nil {
+		return CheckinResult{}, err
+	}
+
+	return CheckinResult{AlreadyCheckedToday: false, NewRecord: record}, tx.Commit()
+}
+
+// TopN 按连续打卡天数返回前N名，等价于 TopNByMetric(ctx, n, MetricContinuousDays)
+func (m *MySQLStore) TopN(ctx context.Context, n int) (RankIterator, error) {
+	return m.TopNByMetric(ctx, n, MetricContinuousDays)
+}
+
+// TopNByMetric 按指定指标直接在 SQL 里排序取前N名。continuous_days/total_30days 都是相对
+// anchor_date 算出来的，只有 anchor_date 正好等于“今天”的那些行，这两列才等于此刻的真实值：
+// 一个用户只要有一天没被写入（没打卡、也没被 BatchShift 碰过），anchor_date 就停在过去，
+// 他“此刻”的连续打卡天数和最近30天打卡天数其实都已经断了（bit0对应的那天没打卡，也就没有
+// 所谓的"从今天开始的连续天数"），真实值应当是0，不该继续顶着断签前的峰值留在榜单上。
+// 所以这里直接按 anchor_date = 今天 过滤，而不是信任全表的派生列——这也顺带保证了
+// total_30days 对应的恰好是"以今天为终点的最近30天"，而不是某次写入时刻为终点的30天
+func (m *MySQLStore) TopNByMetric(ctx context.Context, n int, metric LeaderboardMetric) (RankIterator, error) {
+	column := "continuous_days"
+	if metric == MetricTotal30Days {
+		column = "total_30days"
+	}
+
+	// today 必须截断到纯日期：anchor_date 是 DATE 列，带着时分秒的 time.Now() 绑进驱动的
+	// 二进制协议会变成 DATETIME，MySQL 比较时把 DATE 列提升为 DATETIME（补 00:00:00）去比，
+	// 几乎永远不等于带时分秒的参数——这张表一整天都会查出空结果，只有零点那一秒例外
+	now := time.Now()
+	y, mo, d := now.Date()
+	today := time.Date(y, mo, d, 0, 0, 0, 0, now.Location())
+	rows, err := m.db.QueryContext(ctx,
+		fmt.Sprintf("SELECT user_id, record FROM user_checkin WHERE anchor_date = ? ORDER BY %s DESC LIMIT ?", column),
+		today, n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []rankEntry
+	for rows.Next() {
+		var userID, dbValue int64
+		if err := rows.Scan(&userID, &dbValue); err != nil {
+			return nil, err
+		}
+		entries = append(entries, rankEntry{userID: userID, record: FromInt64(dbValue)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newSliceRankIterator(entries), nil
+}
+
+// Maintainer 负责维护 user_checkin 表上“应用层维护”的派生列（目前是 continuous_days）
+// Set/BatchShift 已经把日常维护做进了事务内，Maintainer 用在历史数据迁移场景
+type Maintainer struct {
+	db *sql.DB
+}
+
+// NewMaintainer 创建派生列维护器
+func NewMaintainer(db *sql.DB) *Maintainer {
+	return &Maintainer{db: db}
+}
+
+// BackfillContinuousDays 为存量数据回填 continuous_days。
+// 按 batchSize 分批读取、计算、逐行 UPDATE，避免一次性长事务锁表。写入的是"按本次回填时刻
+// 惰性移位之后"算出来的连续天数——对 anchor_date 落后于今天的行，先用 GetDiffDays 算出落后
+// 的天数、shiftBy 移位过去再算 ContinuousDays（断签的用户移位后bit0必然是0，结果就是0），
+// 而不是直接对尚未移位的 record popcount，否则断签很久的用户回填完还是停在断签前的峰值
+func (mt *Maintainer) BackfillContinuousDays(ctx context.Context, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	today := time.Now()
+
+	var lastUserID int64
+	for {
+		rows, err := mt.db.QueryContext(ctx,
+			"SELECT user_id, record, anchor_date FROM user_checkin WHERE user_id > ? ORDER BY user_id LIMIT ?",
+			lastUserID, batchSize,
+		)
+		if err != nil {
+			return err
+		}
+
+		type pending struct {
+			userID         int64
+			continuousDays int
+		}
+		var batch []pending
+		for rows.Next() {
+			var userID, dbValue int64
+			var anchor time.Time
+			if err := rows.Scan(&userID, &dbValue, &anchor); err != nil {
+				rows.Close()
+				return err
+			}
+			shifted := FromInt64(dbValue).shiftBy(GetDiffDays(anchor, today))
+			batch = append(batch, pending{userID: userID, continuousDays: shifted.ContinuousDays()})
+			lastUserID = userID
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		tx, err := mt.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, p := range batch {
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE user_checkin SET continuous_days = ? WHERE user_id = ?",
+				p.continuousDays, p.userID,
+			); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// rankEntry 排行榜的一条记录，供 sliceRankIterator 使用
+type rankEntry struct {
+	userID int64
+	record CheckinRecord
+}
+
+// sortEntriesByContinuousDays 按连续打卡天数降序排序。供没有派生列可用、只能在进程内
+// 排序的后端（LevelDBStore、RedisStore）使用，是 MySQLStore 旧版冒泡排序的替代品
+func sortEntriesByContinuousDays(entries []rankEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].record.ContinuousDays() > entries[j].record.ContinuousDays()
+	})
+}
+
+// sliceRankIterator 基于内存切片的 RankIterator 实现，供各 Store 复用
+type sliceRankIterator struct {
+	entries []rankEntry
+	pos     int
+}
+
+func newSliceRankIterator(entries []rankEntry) *sliceRankIterator {
+	return &sliceRankIterator{entries: entries, pos: -1}
+}
+
+func (it *sliceRankIterator) Next(ctx context.Context) bool {
+	if it.pos+1 >= len(it.entries) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *sliceRankIterator) UserID() int64 {
+	return it.entries[it.pos].userID
+}
+
+func (it *sliceRankIterator) Record() CheckinRecord {
+	return it.entries[it.pos].record
+}
+
+func (it *sliceRankIterator) Err() error {
+	return nil
+}
+
+func (it *sliceRankIterator) Close() error {
+	return nil
+}
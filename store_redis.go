@@ -0,0 +1,213 @@
+package checkin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore 基于 Redis 原生位图的 Store 实现
+// 每个用户一个 bitmap key（"checkin:<id>"），第0位对应 anchor_date 所在的那天（不是今天），
+// anchor 单独存一个 hash（"checkin:anchor"）。bit i 对应 anchor 往前第i天，anchor 落后今天
+// delta 天时，今天不在位图范围内。Set 用一次 SET 覆盖写整条位图；IsCheckedToday 在 delta==0
+// 时用服务端 GETBIT 判断，delta>0 时直接返回false；ContinuousDays/TotalDaysInPeriod 把整条
+// 记录读回来，在Go里 shiftBy(delta) 之后再计算，不在服务端用 SETBIT/BITCOUNT/BITPOS 计算
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore 创建 Redis 存储后端
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func bitmapKey(userID int64) string {
+	return fmt.Sprintf("checkin:%d", userID)
+}
+
+const anchorHashKey = "checkin:anchor"
+
+// Get 读取用户当前的打卡记录与锚点日期；用户不存在时返回零值
+func (r *RedisStore) Get(ctx context.Context, userID int64) (CheckinRecord, time.Time, error) {
+	anchorUnix, err := r.client.HGet(ctx, anchorHashKey, fmt.Sprint(userID)).Int64()
+	if err == redis.Nil {
+		return NewCheckinRecord(), time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	bits, err := r.client.Get(ctx, bitmapKey(userID)).Bytes()
+	if err != nil && err != redis.Nil {
+		return 0, time.Time{}, err
+	}
+	return bitmapToRecord(bits), time.Unix(anchorUnix, 0).UTC(), nil
+}
+
+// Set 写入用户的打卡记录与锚点日期
+func (r *RedisStore) Set(ctx context.Context, userID int64, value CheckinRecord, anchor time.Time) error {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, bitmapKey(userID), recordToBitmap(value), 0)
+	// 只存 anchor 在其自身时区下的日历日期（按UTC零点记），读回时 Date() 得到的还是这一天
+	y, m, d := anchor.Date()
+	pipe.HSet(ctx, anchorHashKey, fmt.Sprint(userID), time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// BatchShift Redis 后端天然支持惰性移位，BatchShift 留空实现（每个用户按自己的 anchor 单独移位即可）
+func (r *RedisStore) BatchShift(ctx context.Context) error {
+	return nil
+}
+
+// TopN 按连续打卡天数返回前N名。原生位图下没有现成的跨用户排序能力，
+// 仍然需要扫描 anchor 哈希里的全部用户再排序。每个用户的 anchor 各不相同，
+// 直接对 Get 回来的原始 record 排序会让断签很久的用户顶着断签前的峰值常驻榜首
+// （和 chunk0-5 里 MySQL 派行榜一开始踩的坑一样），所以排序前先用各自的
+// GetDiffDays(anchor, today) 惰性移位到今天
+func (r *RedisStore) TopN(ctx context.Context, n int) (RankIterator, error) {
+	userIDStrs, err := r.client.HKeys(ctx, anchorHashKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now()
+	var entries []rankEntry
+	for _, idStr := range userIDStrs {
+		var userID int64
+		if _, err := fmt.Sscan(idStr, &userID); err != nil {
+			continue
+		}
+		value, anchor, err := r.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !anchor.IsZero() {
+			value = value.shiftBy(GetDiffDays(anchor, today))
+		}
+		entries = append(entries, rankEntry{userID: userID, record: value})
+	}
+
+	sortEntriesByContinuousDays(entries)
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return newSliceRankIterator(entries), nil
+}
+
+// todayOffset 读取用户的 anchor，返回 anchor 到“今天”的天数差 delta（小于0时按0算）。
+// bit 0 是 anchor_date 那天、bit i 是 anchor 往前第i天，delta>0 时今天不在位图范围内。用户不存在时 ok=false
+func (r *RedisStore) todayOffset(ctx context.Context, userID int64, loc *time.Location) (delta int, ok bool, err error) {
+	anchorUnix, err := r.client.HGet(ctx, anchorHashKey, fmt.Sprint(userID)).Int64()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	anchor := time.Unix(anchorUnix, 0).UTC()
+	today := time.Now().In(loc)
+	delta = GetDiffDays(anchor, today)
+	if delta < 0 {
+		delta = 0
+	}
+	return delta, true, nil
+}
+
+// IsCheckedToday 用 GETBIT 在服务端判断。位图只记录 anchor_date 及更早的日子（bit i 对应
+// anchor往前第i天），delta（anchor到今天的天数差）>0 时，"今天"根本不在位图范围内——
+// 位图里偏移delta处存的是"anchor往前delta天"，不是"今天"，两者方向相反，直接读会把
+// 早已断签的用户误判成今天打过卡。所以 delta>0 时直接返回false（和 ContinuousDays()
+// 对断签用户shiftBy之后必为0是一致的），只有 delta==0（anchor就是今天）才需要真的查位图
+func (r *RedisStore) IsCheckedToday(ctx context.Context, userID int64, loc *time.Location) (bool, error) {
+	delta, ok, err := r.todayOffset(ctx, userID, loc)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+	if delta > 0 {
+		return false, nil
+	}
+	bit, err := r.client.GetBit(ctx, bitmapKey(userID), 0).Result()
+	if err != nil {
+		return false, err
+	}
+	return bit == 1, nil
+}
+
+// ContinuousDays 返回从今天开始的连续打卡天数。位图的 bit 0 是 anchor_date 那天，不是今天，
+// 今天比 anchor 晚 delta 天、不在位图范围内，所以这里不在服务端用 BITPOS/BITCOUNT 计算，
+// 而是用 Get（一次 HGET 加一次 GET，位图只有8字节）读回整条记录，在Go里做 shiftBy(delta) 之后再算
+func (r *RedisStore) ContinuousDays(ctx context.Context, userID int64, loc *time.Location) (int, error) {
+	value, anchor, err := r.Get(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if anchor.IsZero() {
+		return 0, nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	today := time.Now().In(loc)
+	return value.shiftBy(GetDiffDays(anchor, today)).ContinuousDays(), nil
+}
+
+// TotalDaysInPeriod 返回最近days天的总打卡天数。位图里bit i 对应"anchor往前第i天"，
+// 和"今天往前第i天"差着delta天，没办法靠把 BITCOUNT 的位范围整体往后
+// 滑动delta来对齐（那统计的其实是"以anchor为终点的最近days天"，根本不是以今天为终点）。
+// 只能先把整条记录读回来，在Go里 shiftBy(delta) 对齐到今天，再调用和 CheckinRecord
+// 语义一致的 TotalDaysInPeriod——和 ContinuousDays 退回Go计算是同一个道理
+func (r *RedisStore) TotalDaysInPeriod(ctx context.Context, userID int64, days int, loc *time.Location) (int, error) {
+	value, anchor, err := r.Get(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if anchor.IsZero() {
+		return 0, nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	today := time.Now().In(loc)
+	return value.shiftBy(GetDiffDays(anchor, today)).TotalDaysInPeriod(days), nil
+}
+
+// recordToBitmap / bitmapToRecord 在 CheckinRecord 的 int64 表示和 Redis 字符串位图之间转换
+// 约定：CheckinRecord 的 bit 0（anchor_date 那天）存在字节0的最高位，即 Redis 位偏移0，
+// 这样 delta==0 时 GETBIT key 0 就是 IsCheckedToday
+func recordToBitmap(value CheckinRecord) []byte {
+	v := uint64(value.ToInt64())
+	buf := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		buf[i] = reverseBits(byte(v >> (i * 8)))
+	}
+	return buf
+}
+
+func bitmapToRecord(buf []byte) CheckinRecord {
+	var v uint64
+	for i := 0; i < len(buf) && i < 8; i++ {
+		v |= uint64(reverseBits(buf[i])) << (i * 8)
+	}
+	return FromInt64(int64(v))
+}
+
+// reverseBits 翻转字节内的位序：Redis SETBIT/GETBIT 把offset 0 当作字节最高位，
+// 而 CheckinRecord 把 bit 0（最低位）当作"今天"，两者相反，存取时需要翻转一次
+func reverseBits(b byte) byte {
+	var r byte
+	for i := 0; i < 8; i++ {
+		r <<= 1
+		r |= b & 1
+		b >>= 1
+	}
+	return r
+}